@@ -5,12 +5,15 @@ package platform
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	htmltemplate "html/template"
 	"io"
 	"net"
 	"net/http"
 	"net/http/pprof"
 	"path"
+	"reflect"
 	"runtime"
 	"sort"
 	"strings"
@@ -21,6 +24,8 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
@@ -43,6 +48,14 @@ type platformMetrics struct {
 	listenAddr string
 
 	getPluginsEnv func() *plugin.Environment
+
+	// registry is private to this platformMetrics instance, rather than prometheus's global
+	// default registerer, so that constructing a second PlatformService in the same process
+	// (as app-layer tests routinely do) doesn't panic with AlreadyRegisteredError.
+	registry *prometheus.Registry
+
+	pluginHealth  *pluginHealthTracker
+	pluginProcess *pluginProcessCollector
 }
 
 // resetMetrics resets the metrics server. Clears the metrics if the metrics are disabled by the config.
@@ -60,16 +73,23 @@ func (ps *PlatformService) resetMetrics() error {
 		}
 	}
 
+	getPluginsEnv := func() *plugin.Environment {
+		if ps.pluginEnv == nil {
+			return nil
+		}
+		return ps.pluginEnv.GetPluginsEnvironment()
+	}
+
 	ps.metrics = &platformMetrics{
-		cfgFn:       ps.Config,
-		metricsImpl: ps.metricsIFace,
-		logger:      ps.logger,
-		getPluginsEnv: func() *plugin.Environment {
-			if ps.pluginEnv == nil {
-				return nil
-			}
-			return ps.pluginEnv.GetPluginsEnvironment()
-		},
+		cfgFn:         ps.Config,
+		metricsImpl:   ps.metricsIFace,
+		logger:        ps.logger,
+		getPluginsEnv: getPluginsEnv,
+		registry:      prometheus.NewRegistry(),
+		// pluginHealth is owned by PlatformService and reused across resets: RestartCount
+		// backs mattermost_plugin_crashes_total, which must never decrease.
+		pluginHealth:  ps.pluginHealth,
+		pluginProcess: newPluginProcessCollector(getPluginsEnv, ps.logger),
 	}
 
 	if err := ps.metrics.initMetricsRouter(); err != nil {
@@ -80,6 +100,12 @@ func (ps *PlatformService) resetMetrics() error {
 		ps.metricsIFace.Register()
 	}
 
+	// Registered on this service's own registry (rather than through metricsIFace, which is
+	// only wired up when an enterprise metrics implementation is licensed) and scraped via the
+	// unconditional /metrics route below, so the plugin supervisor state and subprocess
+	// resource usage are visible even on servers without that license.
+	ps.metrics.registry.MustRegister(ps.metrics.pluginHealth, ps.metrics.pluginProcess)
+
 	return ps.metrics.startMetricsServer()
 }
 
@@ -87,6 +113,13 @@ func (pm *platformMetrics) stopMetricsServer() error {
 	pm.lock.Lock()
 	defer pm.lock.Unlock()
 
+	if pm.pluginHealth != nil {
+		pm.registry.Unregister(pm.pluginHealth)
+	}
+	if pm.pluginProcess != nil {
+		pm.registry.Unregister(pm.pluginProcess)
+	}
+
 	if pm.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), TimeToWaitForConnectionsToCloseOnServerShutdown)
 		defer cancel()
@@ -123,9 +156,17 @@ func (pm *platformMetrics) startMetricsServer() error {
 		WriteTimeout: time.Duration(*pm.cfgFn().ServiceSettings.WriteTimeout) * time.Second,
 	}
 
+	certFile, keyFile := pm.resolveTLSFiles(*pm.cfgFn().MetricsSettings.TLSCertFile, *pm.cfgFn().MetricsSettings.TLSKeyFile)
+
 	go func() {
 		close(notify)
-		if err := pm.server.Serve(l); err != nil && err != http.ErrServerClosed {
+		var err error
+		if certFile != "" && keyFile != "" {
+			err = pm.server.ServeTLS(l, certFile, keyFile)
+		} else {
+			err = pm.server.Serve(l)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			pm.logger.Fatal(err.Error())
 		}
 	}()
@@ -135,10 +176,31 @@ func (pm *platformMetrics) startMetricsServer() error {
 	return nil
 }
 
+// resolveTLSFiles returns certFile/keyFile unchanged when both or neither are set, enabling TLS
+// via ServeTLS. If only one is set, that's a misconfiguration rather than a valid "TLS disabled"
+// state, so it logs a warning and falls back to plain HTTP instead of silently picking a mode.
+func (pm *platformMetrics) resolveTLSFiles(certFile, keyFile string) (string, string) {
+	if (certFile == "") != (keyFile == "") {
+		pm.logger.Warn("MetricsSettings.TLSCertFile and MetricsSettings.TLSKeyFile must both be set to enable TLS; falling back to plain HTTP")
+		return "", ""
+	}
+	return certFile, keyFile
+}
+
 func (pm *platformMetrics) initMetricsRouter() error {
 	pm.router = mux.NewRouter()
+	pm.router.Use(pm.authMiddleware)
 	runtime.SetBlockProfileRate(*pm.cfgFn().MetricsSettings.BlockProfileRate)
 
+	pm.router.HandleFunc(*pm.cfgFn().MetricsSettings.HealthzRoute, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Mounted unconditionally (independent of metricsIFace.Register(), which only exists when
+	// an enterprise metrics implementation is licensed) so the plugin supervisor and subprocess
+	// collectors registered on pm.registry are always scrapable.
+	pm.router.Handle("/metrics", promhttp.HandlerFor(pm.registry, promhttp.HandlerOpts{}))
+
 	rootMetricsPage := `
 			<html>
 				<body>{{if .}}
@@ -153,6 +215,7 @@ func (pm *platformMetrics) initMetricsRouter() error {
 					<div><a href="/debug/pprof/trace">Profiling Execution Trace</a></div>
 					<div><a href="/debug/pprof/profile">Profiling CPU</a></div>
 					<div><a href="/plugins">Plugins Profiling</a></div>
+					<div><a href="/plugins/statuses">Plugin Statuses</a></div>
 				</body>
 			</html>
 		`
@@ -185,6 +248,7 @@ func (pm *platformMetrics) initMetricsRouter() error {
 
 	pluginsRouter := pm.router.PathPrefix("/plugins").Subrouter()
 	pluginsRouter.HandleFunc("/", pm.serveListPluginsRequest)
+	pluginsRouter.HandleFunc("/statuses", pm.servePluginStatusesRequest)
 
 	pluginMetricsPage := `
 			<html>
@@ -198,6 +262,7 @@ func (pm *platformMetrics) initMetricsRouter() error {
 					<div><a href="debug/pprof/block">Profiling Blocking</a></div>
 					<div><a href="debug/pprof/trace">Profiling Execution Trace</a></div>
 					<div><a href="/debug/pprof/profile">Profiling CPU</a></div>
+					<div><a href="process">Process Stats</a></div>
 				</body>
 			</html>
 		`
@@ -222,6 +287,9 @@ func (pm *platformMetrics) initMetricsRouter() error {
 	debugRouter.Handle("/debug", http.RedirectHandler("/", http.StatusMovedPermanently)) // TODO(hanzei): Maybe add this
 	debugRouter.HandleFunc("/{anything:.*}", pm.servePluginDebugMetricsRequest)
 
+	// Plugin subprocess resource stats route
+	pluginRouter.HandleFunc("/process", pm.servePluginProcessRequest)
+
 	return nil
 }
 
@@ -269,6 +337,116 @@ func (pm *platformMetrics) serveListPluginsRequest(w http.ResponseWriter, r *htt
 	pm.renderTemplate(metricsPageTmpl, r, w, ids)
 }
 
+// servePluginStatusesRequest reports the supervisor state of every plugin that has been
+// activated since the PlatformService was created, either as JSON or, for a browser, as an HTML
+// table linked from the root metrics page.
+func (pm *platformMetrics) servePluginStatusesRequest(w http.ResponseWriter, r *http.Request) {
+	statuses := pm.pluginHealth.snapshot()
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].PluginId < statuses[j].PluginId })
+
+	if r.Header.Get("Accept") == "application/json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(statuses); err != nil {
+			pm.logger.Warn("Failed to encode plugin statuses", mlog.Err(err))
+		}
+		return
+	}
+
+	page := `
+	<html>
+		<body>
+			<table border="1" cellpadding="4">
+				<tr>
+					<th>Plugin ID</th>
+					<th>State</th>
+					<th>Restart Count</th>
+					<th>Last Crash At</th>
+					<th>Last Error</th>
+					<th>Sandboxed</th>
+				</tr>
+				{{range .}}
+				<tr>
+					<td>{{.PluginId}}</td>
+					<td>{{.State}}</td>
+					<td>{{.RestartCount}}</td>
+					<td>{{.LastCrashAt}}</td>
+					<td>{{.LastError}}</td>
+					<td>{{.Sandboxed}}</td>
+				</tr>
+				{{end}}
+			</table>
+		</body>
+	</html>
+`
+	// LastError and PluginId can contain operator- or plugin-supplied text, so this page uses
+	// html/template (unlike the rest of this file) to get contextual auto-escaping.
+	pageTmpl, err := htmltemplate.New("pluginStatusesPage").Parse(page)
+	if err != nil {
+		appErr := model.NewAppError("servePluginStatusesRequest", "app.plugin.disabled.app_error",
+			nil, "failed to create template", http.StatusInternalServerError).Wrap(err)
+		mlog.Error(appErr.Error())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(appErr.StatusCode)
+		w.Write([]byte(appErr.ToJSON()))
+		return
+	}
+
+	if err := pageTmpl.Execute(w, statuses); err != nil {
+		pm.logger.Warn("Failed to render plugin statuses page", mlog.String("path", r.URL.Path), mlog.Err(err))
+	}
+}
+
+// servePluginProcessRequest renders the live resource stats of a plugin's subprocess, the same
+// values the Prometheus collector reports, for operators browsing the debug UI.
+func (pm *platformMetrics) servePluginProcessRequest(w http.ResponseWriter, r *http.Request) {
+	pluginID := mux.Vars(r)["plugin_id"]
+
+	stats, err := pm.pluginProcess.stats(pluginID)
+	if err != nil {
+		statusCode := http.StatusNotFound
+		message := "Plugin not found"
+		if err == errPluginsDisabled {
+			statusCode = http.StatusNotImplemented
+			message = "Enable plugins to serve plugin metric requests"
+		}
+
+		appErr := model.NewAppError("servePluginProcessRequest", "app.plugin.disabled.app_error",
+			nil, message, statusCode).Wrap(err)
+		mlog.Error(appErr.Error())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(appErr.StatusCode)
+		w.Write([]byte(appErr.ToJSON()))
+		return
+	}
+
+	page := `
+	<html>
+		<body>
+			<table border="1" cellpadding="4">
+				<tr><th>PID</th><td>{{.Pid}}</td></tr>
+				<tr><th>Resident Memory (bytes)</th><td>{{.ResidentMemoryBytes}}</td></tr>
+				<tr><th>Virtual Memory (bytes)</th><td>{{.VirtualMemoryBytes}}</td></tr>
+				<tr><th>CPU Seconds</th><td>{{.CPUSeconds}}</td></tr>
+				<tr><th>Open FDs</th><td>{{.OpenFDs}}</td></tr>
+				<tr><th>Threads</th><td>{{.NumThreads}}</td></tr>
+			</table>
+		</body>
+	</html>
+`
+	pageTmpl, err := template.New("pluginProcessPage").Parse(page)
+	if err != nil {
+		appErr := model.NewAppError("servePluginProcessRequest", "app.plugin.disabled.app_error",
+			nil, "failed to create template", http.StatusInternalServerError).Wrap(err)
+		mlog.Error(appErr.Error())
+		w.WriteHeader(appErr.StatusCode)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(appErr.ToJSON()))
+		return
+	}
+
+	pm.renderTemplate(pageTmpl, r, w, stats)
+}
+
 func (pm *platformMetrics) servePluginDebugMetricsRequest(w http.ResponseWriter, r *http.Request) {
 	pluginID := mux.Vars(r)["plugin_id"]
 
@@ -296,8 +474,8 @@ func (pm *platformMetrics) servePluginDebugMetricsRequest(w http.ResponseWriter,
 
 	r.URL.Path = strings.TrimPrefix(r.URL.Path, path.Join(subpath, "plugins", pluginID, "debug"))
 
-	// Passing an empty plugin context for the time being. To be decided whether we
-	// should support forms of authentication in the future.
+	// Authentication is now handled by authMiddleware at the server level, so an empty
+	// plugin context is still passed through here.
 	pluginsEnvironment.ServeDebug(pluginID, &plugin.Context{}, w, r)
 }
 
@@ -338,8 +516,8 @@ func (pm *platformMetrics) servePluginMetricsRequest(w http.ResponseWriter, r *h
 
 	r.URL.Path = strings.TrimPrefix(r.URL.Path, path.Join(subpath, "plugins", pluginID, "metrics"))
 
-	// Passing an empty plugin context for the time being. To be decided whether we
-	// should support forms of authentication in the future.
+	// Authentication is now handled by authMiddleware at the server level, so an empty
+	// plugin context is still passed through here.
 	hooks.ServeMetrics(&plugin.Context{}, w, r)
 }
 
@@ -363,6 +541,17 @@ func (ps *PlatformService) RestartMetrics() error {
 	return ps.resetMetrics()
 }
 
+// OnMetricsConfigChange restarts the metrics server whenever MetricsSettings changes, so that
+// edits to the listen address, auth token, TLS certificate/key, allowed CIDRs, or healthz route
+// take effect without a full server restart.
+func (ps *PlatformService) OnMetricsConfigChange(oldConfig, newConfig *model.Config) error {
+	if reflect.DeepEqual(oldConfig.MetricsSettings, newConfig.MetricsSettings) {
+		return nil
+	}
+
+	return ps.RestartMetrics()
+}
+
 func (ps *PlatformService) Metrics() einterfaces.MetricsInterface {
 	if ps.metrics == nil {
 		return nil
@@ -370,3 +559,28 @@ func (ps *PlatformService) Metrics() einterfaces.MetricsInterface {
 
 	return ps.metricsIFace
 }
+
+// onPluginHealthActivate is called by ActivatePlugin once a plugin's supervisor has been
+// started; onPluginHealthRunning is called once the plugin has finished activating successfully.
+//
+// pluginHealth is tracked on PlatformService regardless of whether the metrics server is
+// currently enabled, so that restart counts survive metrics being toggled off and on.
+func (ps *PlatformService) onPluginHealthActivate(pluginID string, sandboxed bool) {
+	ps.pluginHealth.activated(pluginID, sandboxed)
+}
+
+func (ps *PlatformService) onPluginHealthRunning(pluginID string) {
+	ps.pluginHealth.running(pluginID)
+}
+
+// onPluginHealthCrash is called when a plugin supervisor's Wait() returns a non-nil error,
+// i.e. the plugin's subprocess has died unexpectedly.
+func (ps *PlatformService) onPluginHealthCrash(pluginID string, crashErr error) {
+	ps.pluginHealth.crashed(pluginID, crashErr)
+}
+
+// onPluginHealthDeactivate is called by DeactivatePlugin once a plugin's supervisor has been
+// unregistered from the plugins environment.
+func (ps *PlatformService) onPluginHealthDeactivate(pluginID string) {
+	ps.pluginHealth.deactivated(pluginID)
+}
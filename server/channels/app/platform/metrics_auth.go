@@ -0,0 +1,80 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+// authMiddleware enforces MetricsSettings.AuthToken and MetricsSettings.AllowedCIDRs on every
+// route except MetricsSettings.HealthzRoute. The pprof routes mounted on this same server can
+// dump goroutines, heap, and CPU profiles of a running production server, and the plugin debug
+// passthrough can reach arbitrary plugin-exposed debug handlers, so anything reachable here is
+// treated as sensitive.
+func (pm *platformMetrics) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == *pm.cfgFn().MetricsSettings.HealthzRoute {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !pm.remoteAddrAllowed(r.RemoteAddr) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		if !pm.authTokenValid(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (pm *platformMetrics) authTokenValid(r *http.Request) bool {
+	token := *pm.cfgFn().MetricsSettings.AuthToken
+	if token == "" {
+		return true
+	}
+
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+func (pm *platformMetrics) remoteAddrAllowed(remoteAddr string) bool {
+	allowedCIDRs := pm.cfgFn().MetricsSettings.AllowedCIDRs
+	if len(allowedCIDRs) == 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, cidr := range allowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			pm.logger.Warn("Invalid entry in MetricsSettings.AllowedCIDRs", mlog.String("cidr", cidr), mlog.Err(err))
+			continue
+		}
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
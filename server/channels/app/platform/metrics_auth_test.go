@@ -0,0 +1,71 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+func newTestPlatformMetrics(t *testing.T, settings model.MetricsSettings) *platformMetrics {
+	t.Helper()
+
+	settings.SetDefaults()
+	cfg := &model.Config{MetricsSettings: settings}
+
+	return &platformMetrics{
+		cfgFn:  func() *model.Config { return cfg },
+		logger: mlog.NewTestLogger(),
+	}
+}
+
+func TestAuthTokenValid(t *testing.T) {
+	t.Run("no token configured allows any request", func(t *testing.T) {
+		pm := newTestPlatformMetrics(t, model.MetricsSettings{})
+
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		assert.True(t, pm.authTokenValid(r))
+	})
+
+	t.Run("token configured requires a matching bearer token", func(t *testing.T) {
+		pm := newTestPlatformMetrics(t, model.MetricsSettings{AuthToken: model.NewString("s3cr3t")})
+
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		assert.False(t, pm.authTokenValid(r))
+
+		r.Header.Set("Authorization", "Bearer wrong")
+		assert.False(t, pm.authTokenValid(r))
+
+		r.Header.Set("Authorization", "Bearer s3cr3t")
+		assert.True(t, pm.authTokenValid(r))
+	})
+}
+
+func TestRemoteAddrAllowed(t *testing.T) {
+	t.Run("no CIDRs configured allows any address", func(t *testing.T) {
+		pm := newTestPlatformMetrics(t, model.MetricsSettings{})
+
+		assert.True(t, pm.remoteAddrAllowed("203.0.113.5:1234"))
+	})
+
+	t.Run("CIDRs configured restrict to matching addresses", func(t *testing.T) {
+		pm := newTestPlatformMetrics(t, model.MetricsSettings{AllowedCIDRs: []string{"10.0.0.0/8"}})
+
+		assert.True(t, pm.remoteAddrAllowed("10.1.2.3:5555"))
+		assert.False(t, pm.remoteAddrAllowed("203.0.113.5:1234"))
+	})
+
+	t.Run("invalid CIDR entries are skipped, not fatal", func(t *testing.T) {
+		pm := newTestPlatformMetrics(t, model.MetricsSettings{AllowedCIDRs: []string{"not-a-cidr", "10.0.0.0/8"}})
+
+		assert.True(t, pm.remoteAddrAllowed("10.1.2.3:5555"))
+		assert.False(t, pm.remoteAddrAllowed("203.0.113.5:1234"))
+	})
+}
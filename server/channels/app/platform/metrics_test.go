@@ -0,0 +1,61 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+// TestResetMetricsPerServiceRegistry guards against a regression to the global default
+// Prometheus registerer, which panics with AlreadyRegisteredError as soon as a second
+// PlatformService with metrics enabled is constructed in the same process.
+func TestResetMetricsPerServiceRegistry(t *testing.T) {
+	newEnabledService := func() *PlatformService {
+		cfg := &model.Config{}
+		cfg.SetDefaults()
+		*cfg.MetricsSettings.Enable = true
+		*cfg.MetricsSettings.ListenAddress = ":0"
+
+		return NewPlatformService(func() *model.Config { return cfg }, mlog.NewTestLogger(), nil)
+	}
+
+	first := newEnabledService()
+	require.NoError(t, first.resetMetrics())
+	defer first.metrics.stopMetricsServer()
+
+	second := newEnabledService()
+	require.NoError(t, second.resetMetrics())
+	defer second.metrics.stopMetricsServer()
+}
+
+func TestResolveTLSFiles(t *testing.T) {
+	pm := &platformMetrics{logger: mlog.NewTestLogger()}
+
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		wantCert string
+		wantKey  string
+	}{
+		{name: "neither set", certFile: "", keyFile: "", wantCert: "", wantKey: ""},
+		{name: "both set", certFile: "cert.pem", keyFile: "key.pem", wantCert: "cert.pem", wantKey: "key.pem"},
+		{name: "only cert set falls back to plain HTTP", certFile: "cert.pem", keyFile: "", wantCert: "", wantKey: ""},
+		{name: "only key set falls back to plain HTTP", certFile: "", keyFile: "key.pem", wantCert: "", wantKey: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gotCert, gotKey := pm.resolveTLSFiles(tc.certFile, tc.keyFile)
+			assert.Equal(t, tc.wantCert, gotCert)
+			assert.Equal(t, tc.wantKey, gotKey)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PluginHealthState describes where a plugin's supervisor currently sits in its lifecycle.
+type PluginHealthState string
+
+const (
+	PluginHealthStateStarting          PluginHealthState = "starting"
+	PluginHealthStateRunning           PluginHealthState = "running"
+	PluginHealthStateFailed            PluginHealthState = "failed"
+	PluginHealthStateStopped           PluginHealthState = "stopped"
+	PluginHealthStatePermanentlyFailed PluginHealthState = "permanently_failed"
+)
+
+// maxPluginCrashesBeforePermanentFailure mirrors the restart budget the supervisor already
+// enforces before it gives up relaunching a plugin.
+const maxPluginCrashesBeforePermanentFailure = 3
+
+// PluginHealthStatus is a point-in-time snapshot of a single plugin's supervisor state,
+// as reported via /plugins/statuses.
+type PluginHealthStatus struct {
+	PluginId     string            `json:"plugin_id"`
+	State        PluginHealthState `json:"state"`
+	RestartCount int               `json:"restart_count"`
+	LastCrashAt  int64             `json:"last_crash_at,omitempty"`
+	LastError    string            `json:"last_error,omitempty"`
+	Sandboxed    bool              `json:"sandboxed"`
+}
+
+// pluginHealthTracker maintains the supervisor lifecycle state of every plugin that has been
+// activated since its owning PlatformService was created (it is kept across metrics server
+// restarts), and reports it both as JSON and as Prometheus metrics.
+type pluginHealthTracker struct {
+	mu       sync.Mutex
+	statuses map[string]*PluginHealthStatus
+
+	// consecutiveFailures counts crashes since the plugin last reached running, reset on a
+	// successful activation. RestartCount, by contrast, is a lifetime total suitable for the
+	// mattermost_plugin_crashes_total counter, which must never decrease.
+	consecutiveFailures map[string]int
+}
+
+func newPluginHealthTracker() *pluginHealthTracker {
+	return &pluginHealthTracker{
+		statuses:            make(map[string]*PluginHealthStatus),
+		consecutiveFailures: make(map[string]int),
+	}
+}
+
+// activated records that a plugin's supervisor has started (or restarted) the plugin.
+func (t *pluginHealthTracker) activated(pluginID string, sandboxed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.statuses[pluginID]
+	if !ok {
+		status = &PluginHealthStatus{PluginId: pluginID}
+		t.statuses[pluginID] = status
+	}
+	status.State = PluginHealthStateStarting
+	status.Sandboxed = sandboxed
+}
+
+// running marks a plugin as having completed activation successfully, resetting its
+// consecutive-failure count.
+func (t *pluginHealthTracker) running(pluginID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.statuses[pluginID]
+	if !ok {
+		return
+	}
+	status.State = PluginHealthStateRunning
+	t.consecutiveFailures[pluginID] = 0
+}
+
+// crashed records a non-nil return from the supervisor's Wait(), incrementing the restart
+// counter and transitioning the plugin to permanently_failed once its consecutive failures
+// exceed the threshold.
+func (t *pluginHealthTracker) crashed(pluginID string, crashErr error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.statuses[pluginID]
+	if !ok {
+		status = &PluginHealthStatus{PluginId: pluginID}
+		t.statuses[pluginID] = status
+	}
+
+	status.RestartCount++
+	status.LastCrashAt = time.Now().Unix()
+	if crashErr != nil {
+		status.LastError = crashErr.Error()
+	}
+
+	t.consecutiveFailures[pluginID]++
+
+	if t.consecutiveFailures[pluginID] > maxPluginCrashesBeforePermanentFailure {
+		status.State = PluginHealthStatePermanentlyFailed
+	} else {
+		status.State = PluginHealthStateFailed
+	}
+}
+
+// deactivated records that the plugin has been deactivated, marking it stopped.
+func (t *pluginHealthTracker) deactivated(pluginID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.statuses[pluginID]
+	if !ok {
+		return
+	}
+	status.State = PluginHealthStateStopped
+}
+
+// snapshot returns a stable, sorted-by-caller copy of the tracked statuses suitable for
+// rendering or serializing.
+func (t *pluginHealthTracker) snapshot() []*PluginHealthStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]*PluginHealthStatus, 0, len(t.statuses))
+	for _, status := range t.statuses {
+		statusCopy := *status
+		statuses = append(statuses, &statusCopy)
+	}
+	return statuses
+}
+
+// Describe implements prometheus.Collector.
+func (t *pluginHealthTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pluginStateDesc
+	ch <- pluginCrashesTotalDesc
+	ch <- pluginLastCrashTimestampDesc
+}
+
+// Collect implements prometheus.Collector, emitting the current state and crash counters for
+// every plugin this tracker has observed.
+func (t *pluginHealthTracker) Collect(ch chan<- prometheus.Metric) {
+	for _, status := range t.snapshot() {
+		for _, state := range []PluginHealthState{
+			PluginHealthStateStarting,
+			PluginHealthStateRunning,
+			PluginHealthStateFailed,
+			PluginHealthStateStopped,
+			PluginHealthStatePermanentlyFailed,
+		} {
+			value := 0.0
+			if status.State == state {
+				value = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(pluginStateDesc, prometheus.GaugeValue, value, status.PluginId, string(state))
+		}
+
+		ch <- prometheus.MustNewConstMetric(pluginCrashesTotalDesc, prometheus.CounterValue, float64(status.RestartCount), status.PluginId)
+
+		if status.LastCrashAt > 0 {
+			ch <- prometheus.MustNewConstMetric(pluginLastCrashTimestampDesc, prometheus.GaugeValue, float64(status.LastCrashAt), status.PluginId)
+		}
+	}
+}
+
+var (
+	pluginStateDesc = prometheus.NewDesc(
+		"mattermost_plugin_state",
+		"Current supervisor state of a plugin, one gauge per (plugin_id, state) pair set to 1 for the active state and 0 otherwise.",
+		[]string{"plugin_id", "state"}, nil,
+	)
+	pluginCrashesTotalDesc = prometheus.NewDesc(
+		"mattermost_plugin_crashes_total",
+		"Total number of times a plugin's supervisor has observed it crash.",
+		[]string{"plugin_id"}, nil,
+	)
+	pluginLastCrashTimestampDesc = prometheus.NewDesc(
+		"mattermost_plugin_last_crash_timestamp_seconds",
+		"Unix timestamp of the last observed crash for a plugin.",
+		[]string{"plugin_id"}, nil,
+	)
+)
@@ -0,0 +1,89 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginHealthTrackerLifecycle(t *testing.T) {
+	t.Run("activate marks starting then running marks running", func(t *testing.T) {
+		tracker := newPluginHealthTracker()
+
+		tracker.activated("plugin-a", true)
+		statuses := tracker.snapshot()
+		require.Len(t, statuses, 1)
+		assert.Equal(t, PluginHealthStateStarting, statuses[0].State)
+		assert.True(t, statuses[0].Sandboxed)
+
+		tracker.running("plugin-a")
+		statuses = tracker.snapshot()
+		require.Len(t, statuses, 1)
+		assert.Equal(t, PluginHealthStateRunning, statuses[0].State)
+	})
+
+	t.Run("crash increments restart count and transitions to failed", func(t *testing.T) {
+		tracker := newPluginHealthTracker()
+
+		tracker.activated("plugin-a", false)
+		tracker.running("plugin-a")
+		tracker.crashed("plugin-a", errors.New("boom"))
+
+		statuses := tracker.snapshot()
+		require.Len(t, statuses, 1)
+		assert.Equal(t, PluginHealthStateFailed, statuses[0].State)
+		assert.Equal(t, 1, statuses[0].RestartCount)
+		assert.Equal(t, "boom", statuses[0].LastError)
+		assert.NotZero(t, statuses[0].LastCrashAt)
+	})
+
+	t.Run("crash count exceeding threshold marks permanently failed", func(t *testing.T) {
+		tracker := newPluginHealthTracker()
+
+		tracker.activated("plugin-a", false)
+		tracker.running("plugin-a")
+		for i := 0; i < maxPluginCrashesBeforePermanentFailure+1; i++ {
+			tracker.crashed("plugin-a", errors.New("boom"))
+		}
+
+		statuses := tracker.snapshot()
+		require.Len(t, statuses, 1)
+		assert.Equal(t, PluginHealthStatePermanentlyFailed, statuses[0].State)
+		assert.Equal(t, maxPluginCrashesBeforePermanentFailure+1, statuses[0].RestartCount)
+	})
+
+	t.Run("restart count never decreases across a successful recovery", func(t *testing.T) {
+		tracker := newPluginHealthTracker()
+
+		tracker.activated("plugin-a", false)
+		tracker.running("plugin-a")
+		tracker.crashed("plugin-a", errors.New("boom"))
+
+		// A plugin recovering after a crash resets its consecutive-failure count, but not the
+		// lifetime RestartCount backing mattermost_plugin_crashes_total.
+		tracker.activated("plugin-a", false)
+		tracker.running("plugin-a")
+
+		statuses := tracker.snapshot()
+		require.Len(t, statuses, 1)
+		assert.Equal(t, PluginHealthStateRunning, statuses[0].State)
+		assert.Equal(t, 1, statuses[0].RestartCount)
+	})
+
+	t.Run("deactivate marks stopped", func(t *testing.T) {
+		tracker := newPluginHealthTracker()
+
+		tracker.activated("plugin-a", false)
+		tracker.running("plugin-a")
+		tracker.deactivated("plugin-a")
+
+		statuses := tracker.snapshot()
+		require.Len(t, statuses, 1)
+		assert.Equal(t, PluginHealthStateStopped, statuses[0].State)
+	})
+}
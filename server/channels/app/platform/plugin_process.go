@@ -0,0 +1,155 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	gopsutil "github.com/shirou/gopsutil/v3/process"
+
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+var errPluginsDisabled = errors.New("plugins are not enabled")
+
+// pluginProcessCollector is a pull-model Prometheus collector: rather than sampling plugin
+// subprocesses on a background ticker, it walks the currently active plugins and reads their
+// OS process stats fresh on every scrape of /metrics.
+type pluginProcessCollector struct {
+	getPluginsEnv func() *plugin.Environment
+	logger        *mlog.Logger
+}
+
+func newPluginProcessCollector(getPluginsEnv func() *plugin.Environment, logger *mlog.Logger) *pluginProcessCollector {
+	return &pluginProcessCollector{
+		getPluginsEnv: getPluginsEnv,
+		logger:        logger,
+	}
+}
+
+var (
+	pluginProcessResidentMemoryDesc = prometheus.NewDesc(
+		"mattermost_plugin_process_resident_memory_bytes",
+		"Resident memory of a plugin's subprocess.",
+		[]string{"plugin_id"}, nil,
+	)
+	pluginProcessVirtualMemoryDesc = prometheus.NewDesc(
+		"mattermost_plugin_process_virtual_memory_bytes",
+		"Virtual memory of a plugin's subprocess.",
+		[]string{"plugin_id"}, nil,
+	)
+	pluginProcessCPUSecondsDesc = prometheus.NewDesc(
+		"mattermost_plugin_process_cpu_seconds_total",
+		"Total user and system CPU time spent by a plugin's subprocess, in seconds.",
+		[]string{"plugin_id"}, nil,
+	)
+	pluginProcessOpenFDsDesc = prometheus.NewDesc(
+		"mattermost_plugin_process_open_fds",
+		"Number of open file descriptors of a plugin's subprocess.",
+		[]string{"plugin_id"}, nil,
+	)
+	pluginProcessNumThreadsDesc = prometheus.NewDesc(
+		"mattermost_plugin_process_num_threads",
+		"Number of OS threads of a plugin's subprocess.",
+		[]string{"plugin_id"}, nil,
+	)
+)
+
+// Describe implements prometheus.Collector.
+func (c *pluginProcessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pluginProcessResidentMemoryDesc
+	ch <- pluginProcessVirtualMemoryDesc
+	ch <- pluginProcessCPUSecondsDesc
+	ch <- pluginProcessOpenFDsDesc
+	ch <- pluginProcessNumThreadsDesc
+}
+
+// Collect implements prometheus.Collector, sampling every active plugin's subprocess at scrape
+// time via gopsutil.
+func (c *pluginProcessCollector) Collect(ch chan<- prometheus.Metric) {
+	pluginsEnvironment := c.getPluginsEnv()
+	if pluginsEnvironment == nil {
+		return
+	}
+
+	for _, bundle := range pluginsEnvironment.Active() {
+		pluginID := bundle.Manifest.Id
+
+		pid, err := pluginsEnvironment.ProcessInfo(pluginID)
+		if err != nil {
+			c.logger.Debug("Failed to get plugin process info", mlog.String("plugin_id", pluginID), mlog.Err(err))
+			continue
+		}
+
+		proc, err := gopsutil.NewProcess(pid)
+		if err != nil {
+			c.logger.Debug("Failed to open plugin process", mlog.String("plugin_id", pluginID), mlog.Err(err))
+			continue
+		}
+
+		if memInfo, err := proc.MemoryInfo(); err == nil && memInfo != nil {
+			ch <- prometheus.MustNewConstMetric(pluginProcessResidentMemoryDesc, prometheus.GaugeValue, float64(memInfo.RSS), pluginID)
+			ch <- prometheus.MustNewConstMetric(pluginProcessVirtualMemoryDesc, prometheus.GaugeValue, float64(memInfo.VMS), pluginID)
+		}
+
+		if cpuTimes, err := proc.Times(); err == nil && cpuTimes != nil {
+			ch <- prometheus.MustNewConstMetric(pluginProcessCPUSecondsDesc, prometheus.CounterValue, cpuTimes.User+cpuTimes.System, pluginID)
+		}
+
+		if fds, err := proc.NumFDs(); err == nil {
+			ch <- prometheus.MustNewConstMetric(pluginProcessOpenFDsDesc, prometheus.GaugeValue, float64(fds), pluginID)
+		}
+
+		if threads, err := proc.NumThreads(); err == nil {
+			ch <- prometheus.MustNewConstMetric(pluginProcessNumThreadsDesc, prometheus.GaugeValue, float64(threads), pluginID)
+		}
+	}
+}
+
+// processStats is the snapshot rendered by the /plugins/{plugin_id}/process debug page.
+type processStats struct {
+	PluginId            string
+	Pid                 int32
+	ResidentMemoryBytes uint64
+	VirtualMemoryBytes  uint64
+	CPUSeconds          float64
+	OpenFDs             int32
+	NumThreads          int32
+}
+
+func (c *pluginProcessCollector) stats(pluginID string) (*processStats, error) {
+	pluginsEnvironment := c.getPluginsEnv()
+	if pluginsEnvironment == nil {
+		return nil, errPluginsDisabled
+	}
+
+	pid, err := pluginsEnvironment.ProcessInfo(pluginID)
+	if err != nil {
+		return nil, err
+	}
+
+	proc, err := gopsutil.NewProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &processStats{PluginId: pluginID, Pid: pid}
+
+	if memInfo, err := proc.MemoryInfo(); err == nil && memInfo != nil {
+		stats.ResidentMemoryBytes = memInfo.RSS
+		stats.VirtualMemoryBytes = memInfo.VMS
+	}
+	if cpuTimes, err := proc.Times(); err == nil && cpuTimes != nil {
+		stats.CPUSeconds = cpuTimes.User + cpuTimes.System
+	}
+	if fds, err := proc.NumFDs(); err == nil {
+		stats.OpenFDs = fds
+	}
+	if threads, err := proc.NumThreads(); err == nil {
+		stats.NumThreads = threads
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+func TestPluginProcessCollectorStats(t *testing.T) {
+	t.Run("plugins disabled", func(t *testing.T) {
+		collector := newPluginProcessCollector(func() *plugin.Environment { return nil }, mlog.NewTestLogger())
+
+		_, err := collector.stats("plugin-a")
+		assert.Equal(t, errPluginsDisabled, err)
+	})
+
+	t.Run("plugin not found", func(t *testing.T) {
+		env := plugin.NewEnvironment()
+		collector := newPluginProcessCollector(func() *plugin.Environment { return env }, mlog.NewTestLogger())
+
+		_, err := collector.stats("missing-plugin")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+	"github.com/mattermost/mattermost/server/v8/einterfaces"
+)
+
+// pluginEnvironmentHolder guards the swap of the active *plugin.Environment that happens
+// whenever plugins are globally enabled/disabled or reloaded.
+type pluginEnvironmentHolder struct {
+	mu  sync.RWMutex
+	env *plugin.Environment
+}
+
+func (h *pluginEnvironmentHolder) GetPluginsEnvironment() *plugin.Environment {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.env
+}
+
+func (h *pluginEnvironmentHolder) set(env *plugin.Environment) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.env = env
+}
+
+// PlatformService is the low-level service backing app.Server: it owns the plugin environment,
+// the metrics/pprof server, and the logger, independent of any higher-level channel/team logic.
+type PlatformService struct {
+	cfgFn  func() *model.Config
+	logger *mlog.Logger
+
+	pluginEnv *pluginEnvironmentHolder
+
+	metricsIFace einterfaces.MetricsInterface
+	metrics      *platformMetrics
+
+	// pluginHealth is owned here, rather than by platformMetrics, so that restarting the
+	// metrics server (e.g. on a config change) does not reset plugins' restart counters.
+	pluginHealth *pluginHealthTracker
+}
+
+func NewPlatformService(cfgFn func() *model.Config, logger *mlog.Logger, metricsIFace einterfaces.MetricsInterface) *PlatformService {
+	return &PlatformService{
+		cfgFn:        cfgFn,
+		logger:       logger,
+		pluginEnv:    &pluginEnvironmentHolder{env: plugin.NewEnvironment()},
+		metricsIFace: metricsIFace,
+		pluginHealth: newPluginHealthTracker(),
+	}
+}
+
+func (ps *PlatformService) Config() *model.Config {
+	return ps.cfgFn()
+}
+
+// ActivatePlugin registers a newly started plugin's supervisor, records it as starting in the
+// health tracker, and begins watching for its subprocess to exit unexpectedly.
+func (ps *PlatformService) ActivatePlugin(pluginID string, bundle *plugin.BundleInfo, hooks plugin.Hooks, supervisor plugin.Supervisor, sandboxed bool) error {
+	env := ps.pluginEnv.GetPluginsEnvironment()
+	if env == nil {
+		return errors.New("plugins environment not initialized")
+	}
+
+	ps.onPluginHealthActivate(pluginID, sandboxed)
+
+	env.RegisterSupervisor(pluginID, bundle, supervisor, hooks, sandboxed)
+
+	go ps.watchPluginSupervisor(pluginID, supervisor)
+
+	ps.onPluginHealthRunning(pluginID)
+
+	return nil
+}
+
+// watchPluginSupervisor loops on the supervisor's Wait() for the lifetime of the plugin,
+// recording a crash every time the subprocess exits unexpectedly. The supervisor restarts the
+// subprocess internally up to its own restart budget, so Wait() can return repeatedly; this
+// loop keeps observing those restarts so consecutive crashes actually accumulate toward
+// maxPluginCrashesBeforePermanentFailure. It returns once Wait() reports a clean exit, i.e. the
+// plugin was deliberately stopped rather than crashing.
+func (ps *PlatformService) watchPluginSupervisor(pluginID string, supervisor plugin.Supervisor) {
+	for {
+		err := supervisor.Wait()
+		if err == nil {
+			return
+		}
+		ps.onPluginHealthCrash(pluginID, err)
+	}
+}
+
+// DeactivatePlugin unregisters a plugin's supervisor from the plugins environment and records
+// the plugin as stopped in the health tracker. It does not stop the supervisor or its subprocess.
+func (ps *PlatformService) DeactivatePlugin(pluginID string) error {
+	env := ps.pluginEnv.GetPluginsEnvironment()
+	if env == nil {
+		return errors.New("plugins environment not initialized")
+	}
+
+	env.UnregisterSupervisor(pluginID)
+
+	ps.onPluginHealthDeactivate(pluginID)
+
+	return nil
+}
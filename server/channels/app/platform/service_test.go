@@ -0,0 +1,103 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package platform
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
+)
+
+// fakeSupervisor is a plugin.Supervisor whose Wait() is controlled by the test, simulating a
+// plugin subprocess that later crashes.
+type fakeSupervisor struct {
+	waitErr chan error
+}
+
+func newFakeSupervisor() *fakeSupervisor {
+	return &fakeSupervisor{waitErr: make(chan error, 1)}
+}
+
+func (s *fakeSupervisor) Wait() error { return <-s.waitErr }
+func (s *fakeSupervisor) Pid() (int32, error) { return 1234, nil }
+
+func newTestPlatformService(t *testing.T) *PlatformService {
+	t.Helper()
+
+	cfg := &model.Config{}
+	cfg.SetDefaults()
+
+	return NewPlatformService(func() *model.Config { return cfg }, mlog.NewTestLogger(), nil)
+}
+
+func TestActivatePluginTracksCrash(t *testing.T) {
+	ps := newTestPlatformService(t)
+	supervisor := newFakeSupervisor()
+	bundle := &plugin.BundleInfo{Manifest: &plugin.Manifest{Id: "plugin-a"}}
+
+	err := ps.ActivatePlugin("plugin-a", bundle, nil, supervisor, false)
+	require.NoError(t, err)
+
+	statuses := ps.pluginHealth.snapshot()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, PluginHealthStateRunning, statuses[0].State)
+
+	supervisor.waitErr <- errors.New("plugin subprocess exited unexpectedly")
+
+	require.Eventually(t, func() bool {
+		statuses := ps.pluginHealth.snapshot()
+		return len(statuses) == 1 && statuses[0].State == PluginHealthStateFailed
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestActivatePluginTracksRepeatedCrashesAsPermanentlyFailed(t *testing.T) {
+	ps := newTestPlatformService(t)
+	supervisor := newFakeSupervisor()
+	bundle := &plugin.BundleInfo{Manifest: &plugin.Manifest{Id: "plugin-a"}}
+
+	require.NoError(t, ps.ActivatePlugin("plugin-a", bundle, nil, supervisor, false))
+
+	// The supervisor restarts the subprocess internally and keeps crashing; the watcher loop
+	// must keep observing those crashes, without an intervening "running" reset, so they
+	// accumulate toward maxPluginCrashesBeforePermanentFailure.
+	for i := 0; i < maxPluginCrashesBeforePermanentFailure+1; i++ {
+		wantRestartCount := i + 1
+		supervisor.waitErr <- errors.New("plugin subprocess exited unexpectedly")
+
+		require.Eventually(t, func() bool {
+			statuses := ps.pluginHealth.snapshot()
+			return len(statuses) == 1 && statuses[0].RestartCount == wantRestartCount
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	statuses := ps.pluginHealth.snapshot()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, PluginHealthStatePermanentlyFailed, statuses[0].State)
+
+	// Unblock the watcher goroutine so the test doesn't leak it.
+	supervisor.waitErr <- nil
+}
+
+func TestDeactivatePluginTracksStopped(t *testing.T) {
+	ps := newTestPlatformService(t)
+	supervisor := newFakeSupervisor()
+	bundle := &plugin.BundleInfo{Manifest: &plugin.Manifest{Id: "plugin-a"}}
+
+	require.NoError(t, ps.ActivatePlugin("plugin-a", bundle, nil, supervisor, false))
+	require.NoError(t, ps.DeactivatePlugin("plugin-a"))
+
+	statuses := ps.pluginHealth.snapshot()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, PluginHealthStateStopped, statuses[0].State)
+
+	// Unblock the watcher goroutine so the test doesn't leak it.
+	supervisor.waitErr <- nil
+}
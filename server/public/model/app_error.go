@@ -0,0 +1,49 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+import "fmt"
+
+// AppError is the error type returned across API boundaries throughout the server, carrying
+// enough detail to both log server-side and translate into a client-facing response.
+type AppError struct {
+	Id            string `json:"id"`
+	Message       string `json:"message"`
+	DetailedError string `json:"detailed_error"`
+	StatusCode    int    `json:"status_code"`
+	where         string
+	wrapped       error
+}
+
+func NewAppError(where string, id string, params map[string]any, details string, status int) *AppError {
+	return &AppError{
+		Id:            id,
+		Message:       id,
+		DetailedError: details,
+		StatusCode:    status,
+		where:         where,
+	}
+}
+
+func (e *AppError) Error() string {
+	if e.DetailedError != "" {
+		return fmt.Sprintf("%s: %s: %s", e.where, e.Message, e.DetailedError)
+	}
+	return fmt.Sprintf("%s: %s", e.where, e.Message)
+}
+
+func (e *AppError) Unwrap() error {
+	return e.wrapped
+}
+
+// Wrap attaches the underlying error so it's preserved by errors.Unwrap while the AppError
+// keeps presenting a stable, user-facing Message/Id.
+func (e *AppError) Wrap(err error) *AppError {
+	e.wrapped = err
+	return e
+}
+
+func (e *AppError) ToJSON() string {
+	return fmt.Sprintf(`{"id":%q,"message":%q,"detailed_error":%q,"status_code":%d}`, e.Id, e.Message, e.DetailedError, e.StatusCode)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package model
+
+// Config is the root of the server's configuration tree. Only the pieces consumed by the
+// metrics/pprof server are modeled here.
+type Config struct {
+	ServiceSettings ServiceSettings
+	MetricsSettings MetricsSettings
+}
+
+type ServiceSettings struct {
+	ReadTimeout  *int
+	WriteTimeout *int
+}
+
+// MetricsSettings controls the standalone metrics/pprof HTTP server started by
+// platformMetrics.
+type MetricsSettings struct {
+	Enable           *bool
+	ListenAddress    *string
+	BlockProfileRate *int
+
+	// AuthToken, when non-empty, is compared against the bearer token on every request to the
+	// metrics server (except HealthzRoute) using a constant-time comparison.
+	AuthToken *string
+	// TLSCertFile and TLSKeyFile, when both set, cause the metrics server to listen with TLS
+	// instead of plaintext HTTP.
+	TLSCertFile *string
+	TLSKeyFile  *string
+	// AllowedCIDRs, when non-empty, restricts access to the metrics server to remote addresses
+	// contained in at least one of the listed CIDRs.
+	AllowedCIDRs []string
+	// HealthzRoute is the path exempted from AuthToken/AllowedCIDRs enforcement so
+	// orchestrators can probe liveness without a token. Defaults to "/healthz".
+	HealthzRoute *string
+}
+
+func (s *MetricsSettings) SetDefaults() {
+	if s.Enable == nil {
+		s.Enable = NewBool(false)
+	}
+
+	if s.ListenAddress == nil {
+		s.ListenAddress = NewString(":8067")
+	}
+
+	if s.BlockProfileRate == nil {
+		s.BlockProfileRate = NewInt(0)
+	}
+
+	if s.AuthToken == nil {
+		s.AuthToken = NewString("")
+	}
+
+	if s.TLSCertFile == nil {
+		s.TLSCertFile = NewString("")
+	}
+
+	if s.TLSKeyFile == nil {
+		s.TLSKeyFile = NewString("")
+	}
+
+	if s.HealthzRoute == nil {
+		s.HealthzRoute = NewString("/healthz")
+	}
+}
+
+func (s *MetricsSettings) isValid() *AppError {
+	if (*s.TLSCertFile == "") != (*s.TLSKeyFile == "") {
+		return NewAppError("Config.IsValid", "model.config.is_valid.metrics_tls.app_error", nil, "", 0)
+	}
+
+	if *s.HealthzRoute == "" {
+		return NewAppError("Config.IsValid", "model.config.is_valid.metrics_healthz_route.app_error", nil, "", 0)
+	}
+
+	return nil
+}
+
+func (c *Config) SetDefaults() {
+	c.MetricsSettings.SetDefaults()
+}
+
+func (c *Config) IsValid() *AppError {
+	return c.MetricsSettings.isValid()
+}
+
+func NewBool(b bool) *bool       { return &b }
+func NewInt(n int) *int          { return &n }
+func NewString(s string) *string { return &s }
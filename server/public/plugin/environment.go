@@ -0,0 +1,134 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Context carries request-scoped plugin call information. It is currently unused by the debug
+// and metrics passthrough routes, which call into plugins without an authenticated session.
+type Context struct{}
+
+type Manifest struct {
+	Id string
+}
+
+// BundleInfo describes a loaded plugin bundle.
+type BundleInfo struct {
+	Manifest *Manifest
+}
+
+// Hooks is the subset of a plugin's RPC hooks the metrics server calls into directly.
+type Hooks interface {
+	ServeMetrics(context *Context, w http.ResponseWriter, r *http.Request)
+}
+
+// Supervisor manages a single plugin's go-plugin subprocess: starting it, restarting it on
+// unexpected exit (up to its own restart budget), and exposing its liveness and PID.
+type Supervisor interface {
+	// Wait blocks until the plugin's subprocess exits, returning a non-nil error if it exited
+	// unexpectedly (i.e. crashed, as opposed to a clean Stop()).
+	Wait() error
+	// Pid returns the OS process id of the plugin's subprocess, for sampling its resource usage
+	// from the host process.
+	Pid() (int32, error)
+}
+
+type activePlugin struct {
+	bundle     *BundleInfo
+	supervisor Supervisor
+	hooks      Hooks
+	sandboxed  bool
+}
+
+// Environment tracks every currently active plugin, each backed by a Supervisor managing its
+// subprocess.
+type Environment struct {
+	mu      sync.RWMutex
+	plugins map[string]*activePlugin
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{
+		plugins: make(map[string]*activePlugin),
+	}
+}
+
+// Active returns the bundle info of every currently active plugin.
+func (e *Environment) Active() []*BundleInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	bundles := make([]*BundleInfo, 0, len(e.plugins))
+	for _, p := range e.plugins {
+		bundles = append(bundles, p.bundle)
+	}
+	return bundles
+}
+
+// HooksForPlugin returns the RPC hooks for an active plugin.
+func (e *Environment) HooksForPlugin(pluginID string) (Hooks, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	p, ok := e.plugins[pluginID]
+	if !ok {
+		return nil, fmt.Errorf("plugin not found: %s", pluginID)
+	}
+	return p.hooks, nil
+}
+
+// ServeDebug answers every plugin debug request (pprof, etc.) with 404 Not Found; requests are
+// not proxied to the plugin.
+func (e *Environment) ServeDebug(pluginID string, context *Context, w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
+
+// IsSandboxed reports whether the given plugin's supervisor runs it inside the OS-level
+// sandbox, as opposed to unsandboxed or not found.
+func (e *Environment) IsSandboxed(pluginID string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	p, ok := e.plugins[pluginID]
+	return ok && p.sandboxed
+}
+
+// ProcessInfo returns the OS PID of the plugin's supervised subprocess, for sampling its
+// resource usage from the host process.
+func (e *Environment) ProcessInfo(pluginID string) (int32, error) {
+	e.mu.RLock()
+	p, ok := e.plugins[pluginID]
+	e.mu.RUnlock()
+
+	if !ok {
+		return 0, fmt.Errorf("plugin not found: %s", pluginID)
+	}
+	return p.supervisor.Pid()
+}
+
+// RegisterSupervisor records a newly activated plugin's supervisor, making it visible to
+// Active/HooksForPlugin.
+func (e *Environment) RegisterSupervisor(pluginID string, bundle *BundleInfo, supervisor Supervisor, hooks Hooks, sandboxed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.plugins[pluginID] = &activePlugin{
+		bundle:     bundle,
+		supervisor: supervisor,
+		hooks:      hooks,
+		sandboxed:  sandboxed,
+	}
+}
+
+// UnregisterSupervisor removes a plugin once it has been deactivated.
+func (e *Environment) UnregisterSupervisor(pluginID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.plugins, pluginID)
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package mlog is the server's structured logger. Only the surface consumed by the metrics/pprof
+// server is modeled here.
+package mlog
+
+import (
+	"fmt"
+	"log"
+)
+
+// Field is a single structured key/value pair attached to a log line.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Field { return Field{Key: key, Value: value} }
+
+func Err(err error) Field { return Field{Key: "error", Value: err} }
+
+// Logger writes structured log lines. The zero value is usable.
+type Logger struct {
+	prefix string
+}
+
+// NewTestLogger returns a Logger suitable for unit tests.
+func NewTestLogger() *Logger {
+	return &Logger{}
+}
+
+func (l *Logger) log(level, msg string, fields []Field) {
+	line := fmt.Sprintf("[%s] %s", level, msg)
+	for _, f := range fields {
+		line += fmt.Sprintf(" %s=%v", f.Key, f.Value)
+	}
+	log.Println(line)
+}
+
+func (l *Logger) Debug(msg string, fields ...Field) { l.log("debug", msg, fields) }
+func (l *Logger) Info(msg string, fields ...Field)  { l.log("info", msg, fields) }
+func (l *Logger) Warn(msg string, fields ...Field)  { l.log("warn", msg, fields) }
+func (l *Logger) Error(msg string, fields ...Field) { l.log("error", msg, fields) }
+func (l *Logger) Fatal(msg string, fields ...Field) { l.log("fatal", msg, fields) }
+
+var defaultLogger = &Logger{}
+
+func Debug(msg string, fields ...Field) { defaultLogger.Debug(msg, fields...) }
+func Info(msg string, fields ...Field)  { defaultLogger.Info(msg, fields...) }
+func Warn(msg string, fields ...Field)  { defaultLogger.Warn(msg, fields...) }
+func Error(msg string, fields ...Field) { defaultLogger.Error(msg, fields...) }
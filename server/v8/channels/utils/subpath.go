@@ -0,0 +1,14 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package utils
+
+import (
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// GetSubpathFromConfig returns the subpath that handlers strip from request paths. The Config
+// modeled here has no SiteURL, so it always returns an empty subpath and a nil error.
+func GetSubpathFromConfig(cfg *model.Config) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,12 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package einterfaces
+
+// MetricsInterface is implemented by the enterprise metrics package and injects the
+// Prometheus registry used by the rest of the server to record application metrics.
+type MetricsInterface interface {
+	// Register mounts this implementation's own collectors and HTTP handler (typically
+	// "/metrics") onto the metrics server via PlatformService.HandleMetrics.
+	Register()
+}